@@ -0,0 +1,133 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"strings"
+	"sync"
+)
+
+// baggagePrefix namespaces request headers that are always propagated to
+// downstream FContexts created by Clone, regardless of the
+// RegisterPropagatedHeader registry.
+const baggagePrefix = "baggage."
+
+// reservedHeaders are the request headers Frugal itself manages. They
+// always survive Clone; user code should treat them as read-only.
+var reservedHeaders = map[string]bool{
+	cidHeader:         true,
+	opIDHeader:        true,
+	timeoutHeader:     true,
+	deadlineHeader:    true,
+	traceparentHeader: true,
+	tracestateHeader:  true,
+	hdrCodecHeader:    true,
+	methodHeader:      true,
+}
+
+var (
+	propagatedHeadersMu sync.RWMutex
+	propagatedHeaders   = make(map[string]bool)
+
+	scrubbedHeadersMu sync.RWMutex
+	scrubbedHeaders   = make(map[string]bool)
+)
+
+// RegisterPropagatedHeader marks a request header name as "propagated": it
+// will be carried over to downstream FContexts created by Clone, the same
+// as baggage.* headers and Frugal's own reserved headers. Headers that
+// aren't registered (and aren't under baggage.*) are "local" to the
+// FContext they were added to and are dropped by Clone.
+func RegisterPropagatedHeader(name string) {
+	propagatedHeadersMu.Lock()
+	propagatedHeaders[name] = true
+	propagatedHeadersMu.Unlock()
+}
+
+// RegisterScrubbedHeader marks a request header name to be stripped from an
+// inbound FContext before it's handed to a user handler, via ScrubHeaders.
+// This is meant for transport/auth headers (e.g. "authorization", "cookie")
+// that a handler should never see, so it can't accidentally forward them on
+// an outbound call it makes.
+func RegisterScrubbedHeader(name string) {
+	scrubbedHeadersMu.Lock()
+	scrubbedHeaders[name] = true
+	scrubbedHeadersMu.Unlock()
+}
+
+// ScrubHeaders returns a copy of ctx with any header registered via
+// RegisterScrubbedHeader removed. Generated processor code calls this on
+// the FContext decoded from an inbound request before invoking the user's
+// handler.
+func ScrubHeaders(ctx FContext) FContext {
+	headers := ctx.RequestHeaders()
+
+	scrubbedHeadersMu.RLock()
+	for name := range scrubbedHeaders {
+		delete(headers, name)
+	}
+	scrubbedHeadersMu.RUnlock()
+
+	scrubbed := &FContextImpl{
+		requestHeaders:      headers,
+		responseHeaders:     ctx.ResponseHeaders(),
+		ephemeralProperties: make(map[interface{}]interface{}),
+		parentSpanID:        ctx.ParentSpanID(),
+	}
+	if fctxEP, ok := ctx.(FContextWithEphemeralProperties); ok {
+		scrubbed.ephemeralProperties = fctxEP.EphemeralProperties()
+	}
+	if fctxGC, ok := ctx.(FContextWithGoContext); ok {
+		scrubbed.goCtx = fctxGC.Context()
+	}
+	return scrubbed
+}
+
+// propagatedRequestHeaders filters headers down to the ones Clone should
+// carry to a downstream FContext: Frugal's own reserved headers, anything
+// under baggage.*, and anything registered via RegisterPropagatedHeader.
+// Everything else is local to the FContext it was added to.
+func propagatedRequestHeaders(headers map[string]string) map[string]string {
+	propagatedHeadersMu.RLock()
+	defer propagatedHeadersMu.RUnlock()
+
+	out := make(map[string]string, len(headers))
+	for name, value := range headers {
+		if reservedHeaders[name] || propagatedHeaders[name] || strings.HasPrefix(name, baggagePrefix) {
+			out[name] = value
+		}
+	}
+	return out
+}
+
+// AddBaggage adds a baggage entry under the given key. Baggage is a
+// propagated request header namespaced under baggage.*: it flows to every
+// downstream FContext created by Clone, unlike a plain request header
+// added with AddRequestHeader. Returns the same FContext to allow for
+// chaining calls.
+func (c *FContextImpl) AddBaggage(key, value string) FContext {
+	return c.AddRequestHeader(baggagePrefix+key, value)
+}
+
+// Baggage returns a copy of the context's baggage, keyed without the
+// baggage. namespace prefix.
+func (c *FContextImpl) Baggage() map[string]string {
+	baggage := make(map[string]string)
+	for name, value := range c.RequestHeaders() {
+		if strings.HasPrefix(name, baggagePrefix) {
+			baggage[strings.TrimPrefix(name, baggagePrefix)] = value
+		}
+	}
+	return baggage
+}
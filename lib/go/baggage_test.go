@@ -0,0 +1,68 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import "testing"
+
+func TestBaggagePropagatesThroughClone(t *testing.T) {
+	ctx := NewFContext("")
+	ctx.AddBaggage("user", "alice")
+
+	clone := Clone(ctx)
+
+	if v, ok := clone.RequestHeader(baggagePrefix + "user"); !ok || v != "alice" {
+		t.Fatalf("expected baggage.user to survive Clone, got %q, ok=%v", v, ok)
+	}
+	if got := clone.Baggage()["user"]; got != "alice" {
+		t.Fatalf("expected Baggage() to return %q, got %q", "alice", got)
+	}
+}
+
+func TestLocalHeaderDoesNotPropagateThroughClone(t *testing.T) {
+	ctx := NewFContext("")
+	ctx.AddRequestHeader("local-only", "value")
+
+	clone := Clone(ctx)
+
+	if _, ok := clone.RequestHeader("local-only"); ok {
+		t.Fatal("expected an unregistered local header to be dropped by Clone")
+	}
+}
+
+func TestRegisteredHeaderPropagatesThroughClone(t *testing.T) {
+	RegisterPropagatedHeader("x-tenant")
+	ctx := NewFContext("")
+	ctx.AddRequestHeader("x-tenant", "acme")
+
+	clone := Clone(ctx)
+
+	if v, ok := clone.RequestHeader("x-tenant"); !ok || v != "acme" {
+		t.Fatalf("expected registered header x-tenant to survive Clone, got %q, ok=%v", v, ok)
+	}
+}
+
+func TestScrubHeadersRemovesRegisteredHeader(t *testing.T) {
+	RegisterScrubbedHeader("authorization")
+	ctx := NewFContext("")
+	ctx.AddRequestHeader("authorization", "Bearer secret")
+
+	scrubbed := ScrubHeaders(ctx)
+
+	if _, ok := scrubbed.RequestHeader("authorization"); ok {
+		t.Fatal("expected authorization header to be scrubbed")
+	}
+	if scrubbed.CorrelationID() != ctx.CorrelationID() {
+		t.Fatal("expected ScrubHeaders to preserve unrelated headers")
+	}
+}
@@ -0,0 +1,438 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// Header naming the HeaderCodec that encoded a frame. Each codec's
+	// Encode sets it; nothing in this package reads it back or switches
+	// codecs based on it.
+	hdrCodecHeader = "_hdrcodec"
+
+	// textCodecName and binaryCodecName are the values exchanged in the
+	// _hdrcodec header.
+	textCodecName   = "text"
+	binaryCodecName = "binary"
+
+	// textCodecMagic and binaryCodecMagic tag an encoded frame with the
+	// codec that produced it so DecodeHeaders can dispatch without the
+	// caller knowing in advance which codec a peer used.
+	textCodecMagic   byte = 0x00
+	binaryCodecMagic byte = 0x01
+
+	// binaryCodecFlagCompressed marks a binary frame whose payload was run
+	// through the codec's Compressor.
+	binaryCodecFlagCompressed byte = 0x01
+
+	// defaultCompressThreshold is the encoded payload size, in bytes, above
+	// which BinaryHeaderCodec compresses the frame.
+	defaultCompressThreshold = 1024
+)
+
+// headerInts are the headers BinaryHeaderCodec tries to pack as varints
+// rather than strings, since they're always non-negative integers on the
+// wire today.
+var headerInts = map[string]bool{
+	opIDHeader:     true,
+	timeoutHeader:  true,
+	deadlineHeader: true,
+}
+
+// HeaderCodec encodes and decodes an FContext's request headers to and from
+// a wire frame. Frugal ships TextHeaderCodec, which preserves today's
+// stringly-typed wire format, and BinaryHeaderCodec, a more compact
+// alternative for payloads carrying large tracing/baggage headers.
+type HeaderCodec interface {
+	// Name identifies the codec for the _hdrcodec negotiation header.
+	Name() string
+
+	// Encode serializes an FContext's request headers.
+	Encode(ctx FContext) ([]byte, error)
+
+	// Decode builds an FContext from a frame previously produced by Encode.
+	Decode(frame []byte) (FContext, error)
+}
+
+// DecodeHeaders decodes a frame produced by TextHeaderCodec or
+// BinaryHeaderCodec, dispatching on the frame's leading magic byte rather
+// than requiring the caller to know in advance which of the two the peer
+// used. Frames from any other HeaderCodec implementation are rejected.
+func DecodeHeaders(frame []byte) (FContext, error) {
+	if len(frame) == 0 {
+		return nil, fmt.Errorf("frugal: empty header frame")
+	}
+	switch frame[0] {
+	case textCodecMagic:
+		return new(TextHeaderCodec).Decode(frame)
+	case binaryCodecMagic:
+		return new(BinaryHeaderCodec).Decode(frame)
+	default:
+		return nil, fmt.Errorf("frugal: unrecognized header frame magic byte 0x%02x", frame[0])
+	}
+}
+
+var (
+	defaultHeaderCodecMu sync.RWMutex
+	defaultHeaderCodec   HeaderCodec = new(TextHeaderCodec)
+)
+
+// DefaultHeaderCodec returns the process-wide default HeaderCodec:
+// TextHeaderCodec unless changed with SetDefaultHeaderCodec. Nothing in this
+// package encodes with it on its own; callers pick it up explicitly.
+//
+// This package only covers encoding/decoding and this default-codec setting;
+// it does not wire codec selection into per-connection protocol
+// negotiation. Doing that would mean adding an FProtocolFactory option that
+// consults DefaultHeaderCodec/SetDefaultHeaderCodec when constructing a
+// connection's protocol, but lib/go has no FProtocolFactory or transport
+// package in this tree to add that option to - so for now, SetDefaultHeaderCodec
+// process-wide is the only negotiation mechanism available, and callers that
+// need per-connection codec selection must call DecodeHeaders (which already
+// dispatches on the frame's magic byte) and manage the choice themselves.
+func DefaultHeaderCodec() HeaderCodec {
+	defaultHeaderCodecMu.RLock()
+	defer defaultHeaderCodecMu.RUnlock()
+	return defaultHeaderCodec
+}
+
+// SetDefaultHeaderCodec changes the HeaderCodec returned by
+// DefaultHeaderCodec.
+func SetDefaultHeaderCodec(codec HeaderCodec) {
+	defaultHeaderCodecMu.Lock()
+	defaultHeaderCodec = codec
+	defaultHeaderCodecMu.Unlock()
+}
+
+// TextHeaderCodec encodes headers as a magic byte followed by a count and,
+// for each header, a length-prefixed name and a length-prefixed value
+// string. It's the format frugal has always used on the wire.
+type TextHeaderCodec struct{}
+
+// Name identifies this codec for the _hdrcodec negotiation header.
+func (c *TextHeaderCodec) Name() string { return textCodecName }
+
+// Encode serializes ctx's request headers as text.
+func (c *TextHeaderCodec) Encode(ctx FContext) ([]byte, error) {
+	headers := ctx.RequestHeaders()
+	headers[hdrCodecHeader] = textCodecName
+
+	var buf bytes.Buffer
+	buf.WriteByte(textCodecMagic)
+	writeUint32(&buf, uint32(len(headers)))
+	for name, value := range headers {
+		writeUint32(&buf, uint32(len(name)))
+		buf.WriteString(name)
+		writeUint32(&buf, uint32(len(value)))
+		buf.WriteString(value)
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode builds an FContext from a text frame produced by Encode.
+func (c *TextHeaderCodec) Decode(frame []byte) (FContext, error) {
+	buf := bytes.NewReader(frame)
+	magic, err := buf.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if magic != textCodecMagic {
+		return nil, fmt.Errorf("frugal: frame is not a text header frame")
+	}
+
+	count, err := readUint32(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string, count)
+	for i := uint32(0); i < count; i++ {
+		name, err := readString(buf)
+		if err != nil {
+			return nil, err
+		}
+		value, err := readString(buf)
+		if err != nil {
+			return nil, err
+		}
+		headers[name] = value
+	}
+	return fContextFromHeaders(headers), nil
+}
+
+// BinaryHeaderCodec encodes headers as a magic byte, a compression flag,
+// and a length-prefixed sequence of entries, packing known integer headers
+// (opid, timeout, deadline) as varints instead of decimal strings. Frames
+// larger than CompressThreshold are compressed with Compressor before
+// being written.
+type BinaryHeaderCodec struct {
+	// CompressThreshold is the uncompressed payload size, in bytes, above
+	// which the frame is compressed. Zero uses defaultCompressThreshold.
+	CompressThreshold int
+
+	// Compressor compresses frames over CompressThreshold. Nil uses
+	// DefaultCompressor, which is backed by zstd.
+	Compressor Compressor
+}
+
+// Name identifies this codec for the _hdrcodec negotiation header.
+func (c *BinaryHeaderCodec) Name() string { return binaryCodecName }
+
+func (c *BinaryHeaderCodec) threshold() int {
+	if c.CompressThreshold > 0 {
+		return c.CompressThreshold
+	}
+	return defaultCompressThreshold
+}
+
+func (c *BinaryHeaderCodec) compressor() Compressor {
+	if c.Compressor != nil {
+		return c.Compressor
+	}
+	return DefaultCompressor
+}
+
+// Encode serializes ctx's request headers in frugal's compact binary
+// format.
+func (c *BinaryHeaderCodec) Encode(ctx FContext) ([]byte, error) {
+	headers := ctx.RequestHeaders()
+	headers[hdrCodecHeader] = binaryCodecName
+
+	var payload bytes.Buffer
+	writeVarint(&payload, uint64(len(headers)))
+	for name, value := range headers {
+		writeVarint(&payload, uint64(len(name)))
+		payload.WriteString(name)
+
+		if headerInts[name] {
+			if n, err := strconv.ParseUint(value, 10, 64); err == nil {
+				payload.WriteByte(1)
+				writeVarint(&payload, n)
+				continue
+			}
+		}
+		payload.WriteByte(0)
+		writeVarint(&payload, uint64(len(value)))
+		payload.WriteString(value)
+	}
+
+	var frame bytes.Buffer
+	frame.WriteByte(binaryCodecMagic)
+	if payload.Len() > c.threshold() {
+		compressed, err := c.compressor().Compress(payload.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		frame.WriteByte(binaryCodecFlagCompressed)
+		writeVarint(&frame, uint64(payload.Len()))
+		frame.Write(compressed)
+	} else {
+		frame.WriteByte(0)
+		frame.Write(payload.Bytes())
+	}
+	return frame.Bytes(), nil
+}
+
+// Decode builds an FContext from a binary frame produced by Encode.
+func (c *BinaryHeaderCodec) Decode(frame []byte) (FContext, error) {
+	buf := bytes.NewReader(frame)
+	magic, err := buf.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if magic != binaryCodecMagic {
+		return nil, fmt.Errorf("frugal: frame is not a binary header frame")
+	}
+
+	compressedFlag, err := buf.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	payload := buf
+	if compressedFlag == binaryCodecFlagCompressed {
+		uncompressedLen, err := binary.ReadUvarint(buf)
+		if err != nil {
+			return nil, err
+		}
+		rest := make([]byte, buf.Len())
+		if _, err := buf.Read(rest); err != nil {
+			return nil, err
+		}
+		decompressed, err := c.compressor().Decompress(rest)
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(decompressed)) != uncompressedLen {
+			return nil, fmt.Errorf("frugal: decompressed header frame size mismatch: got %d, want %d", len(decompressed), uncompressedLen)
+		}
+		payload = bytes.NewReader(decompressed)
+	}
+
+	count, err := binary.ReadUvarint(payload)
+	if err != nil {
+		return nil, err
+	}
+	// Every entry takes at least one byte (a zero-length name), so a frame
+	// can't possibly declare more entries than bytes remaining; reject
+	// before sizing the map off an attacker-controlled count.
+	if count > uint64(payload.Len()) {
+		return nil, fmt.Errorf("frugal: binary header frame declares %d entries, exceeding the %d bytes remaining in the frame", count, payload.Len())
+	}
+
+	headers := make(map[string]string, count)
+	for i := uint64(0); i < count; i++ {
+		nameLen, err := binary.ReadUvarint(payload)
+		if err != nil {
+			return nil, err
+		}
+		name, err := readBounded(payload, nameLen)
+		if err != nil {
+			return nil, err
+		}
+
+		isInt, err := payload.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if isInt == 1 {
+			n, err := binary.ReadUvarint(payload)
+			if err != nil {
+				return nil, err
+			}
+			headers[string(name)] = strconv.FormatUint(n, 10)
+			continue
+		}
+
+		valueLen, err := binary.ReadUvarint(payload)
+		if err != nil {
+			return nil, err
+		}
+		value, err := readBounded(payload, valueLen)
+		if err != nil {
+			return nil, err
+		}
+		headers[string(name)] = string(value)
+	}
+	return fContextFromHeaders(headers), nil
+}
+
+// readBounded reads exactly n bytes from r, rejecting n up front if it
+// exceeds the bytes actually remaining rather than trusting a
+// wire-supplied length straight into make([]byte, n) (which a malicious or
+// corrupt frame can set arbitrarily high) and short-reading silently the
+// way a bare Reader.Read call would.
+func readBounded(r *bytes.Reader, n uint64) ([]byte, error) {
+	if n > uint64(r.Len()) {
+		return nil, fmt.Errorf("frugal: binary header frame declares a length of %d bytes, exceeding the %d bytes remaining in the frame", n, r.Len())
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Compressor compresses and decompresses header frames larger than a
+// codec's configured threshold. The default implementation uses zstd (see
+// DefaultCompressor); satisfy this interface to plug in a different one.
+type Compressor interface {
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// zstdCompressor is a Compressor backed by klauspost/compress/zstd, a
+// pure-Go zstd implementation. Its encoder and decoder are safe for
+// concurrent use and are built once at package init with SpeedFastest,
+// since header frames are small and latency-sensitive.
+type zstdCompressor struct {
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+func newZstdCompressor() *zstdCompressor {
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	if err != nil {
+		panic(err)
+	}
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		panic(err)
+	}
+	return &zstdCompressor{encoder: encoder, decoder: decoder}
+}
+
+func (c *zstdCompressor) Compress(data []byte) ([]byte, error) {
+	return c.encoder.EncodeAll(data, nil), nil
+}
+
+func (c *zstdCompressor) Decompress(data []byte) ([]byte, error) {
+	return c.decoder.DecodeAll(data, nil)
+}
+
+// DefaultCompressor is the Compressor BinaryHeaderCodec uses when none is
+// configured.
+var DefaultCompressor Compressor = newZstdCompressor()
+
+// fContextFromHeaders builds an FContext directly from a decoded request
+// header map, bypassing NewFContext's header generation since the frame
+// already carries cid/opid/trace headers from the sender.
+func fContextFromHeaders(headers map[string]string) FContext {
+	return &FContextImpl{
+		requestHeaders:      headers,
+		responseHeaders:     make(map[string]string),
+		ephemeralProperties: make(map[interface{}]interface{}),
+	}
+}
+
+func writeUint32(buf *bytes.Buffer, v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	buf.Write(b[:])
+}
+
+func readUint32(buf *bytes.Reader) (uint32, error) {
+	var b [4]byte
+	if _, err := io.ReadFull(buf, b[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b[:]), nil
+}
+
+func readString(buf *bytes.Reader) (string, error) {
+	n, err := readUint32(buf)
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(buf, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func writeVarint(buf *bytes.Buffer, v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	buf.Write(b[:n])
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestTextHeaderCodecRoundTrip(t *testing.T) {
+	ctx := NewFContext("cid-123")
+	ctx.AddRequestHeader("custom", "value")
+
+	codec := new(TextHeaderCodec)
+	frame, err := codec.Encode(ctx)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	decoded, err := DecodeHeaders(frame)
+	if err != nil {
+		t.Fatalf("DecodeHeaders: %v", err)
+	}
+	if decoded.CorrelationID() != "cid-123" {
+		t.Fatalf("expected cid %q, got %q", "cid-123", decoded.CorrelationID())
+	}
+	if v, _ := decoded.RequestHeader("custom"); v != "value" {
+		t.Fatalf("expected custom header %q, got %q", "value", v)
+	}
+}
+
+func TestBinaryHeaderCodecRoundTripUncompressed(t *testing.T) {
+	ctx := NewFContext("cid-456")
+	ctx.AddRequestHeader("custom", "value")
+
+	codec := new(BinaryHeaderCodec)
+	frame, err := codec.Encode(ctx)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	decoded, err := DecodeHeaders(frame)
+	if err != nil {
+		t.Fatalf("DecodeHeaders: %v", err)
+	}
+	if decoded.CorrelationID() != "cid-456" {
+		t.Fatalf("expected cid %q, got %q", "cid-456", decoded.CorrelationID())
+	}
+	if v, _ := decoded.RequestHeader("custom"); v != "value" {
+		t.Fatalf("expected custom header %q, got %q", "value", v)
+	}
+}
+
+func TestBinaryHeaderCodecRoundTripCompressed(t *testing.T) {
+	ctx := NewFContext("cid-789")
+	ctx.AddRequestHeader("custom", strings.Repeat("x", 2*defaultCompressThreshold))
+
+	codec := &BinaryHeaderCodec{CompressThreshold: 16}
+	frame, err := codec.Encode(ctx)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if frame[1] != binaryCodecFlagCompressed {
+		t.Fatalf("expected frame to be marked compressed")
+	}
+
+	decoded, err := DecodeHeaders(frame)
+	if err != nil {
+		t.Fatalf("DecodeHeaders: %v", err)
+	}
+	if v, _ := decoded.RequestHeader("custom"); v != strings.Repeat("x", 2*defaultCompressThreshold) {
+		t.Fatal("decompressed custom header did not round-trip")
+	}
+}
+
+// TestBinaryHeaderCodecDecodeRejectsOversizedLength guards against a
+// malformed frame declaring a name/value length far larger than the bytes
+// actually remaining, which must be rejected with an error rather than
+// crashing the process via make([]byte, hugeLen) or silently truncating.
+func TestBinaryHeaderCodecDecodeRejectsOversizedLength(t *testing.T) {
+	var frame []byte
+	frame = append(frame, binaryCodecMagic, 0) // magic, uncompressed
+	frame = append(frame, 1)                   // count = 1
+	nameLen := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(nameLen, 1<<62)
+	frame = append(frame, nameLen[:n]...)
+
+	codec := new(BinaryHeaderCodec)
+	if _, err := codec.Decode(frame); err == nil {
+		t.Fatal("expected an error decoding a frame with an oversized declared length")
+	}
+}
+
+// TestBinaryHeaderCodecDecodeRejectsOversizedCount covers the same
+// wire-trusts-attacker-controlled-size class of bug for the entry count
+// itself, not just individual name/value lengths.
+func TestBinaryHeaderCodecDecodeRejectsOversizedCount(t *testing.T) {
+	var frame []byte
+	frame = append(frame, binaryCodecMagic, 0) // magic, uncompressed
+	countBuf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(countBuf, 1<<62)
+	frame = append(frame, countBuf[:n]...)
+
+	codec := new(BinaryHeaderCodec)
+	if _, err := codec.Decode(frame); err == nil {
+		t.Fatal("expected an error decoding a frame with an oversized declared entry count")
+	}
+}
+
+func TestDefaultCompressorRoundTrip(t *testing.T) {
+	data := []byte(strings.Repeat("frugal", 100))
+	compressed, err := DefaultCompressor.Compress(data)
+	if err != nil {
+		t.Fatalf("Compress: %v", err)
+	}
+	decompressed, err := DefaultCompressor.Decompress(compressed)
+	if err != nil {
+		t.Fatalf("Decompress: %v", err)
+	}
+	if string(decompressed) != string(data) {
+		t.Fatal("decompressed data did not match original")
+	}
+}
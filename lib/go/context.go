@@ -15,13 +15,18 @@ package frugal
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/nats-io/nuid"
+
+	"github.com/Workiva/frugal/lib/go/tracing"
 )
 
 const (
@@ -34,8 +39,35 @@ const (
 	// Header containing request timeout (milliseconds as string)
 	timeoutHeader = "_timeout"
 
+	// Header containing the W3C Trace Context traceparent, see
+	// https://www.w3.org/TR/trace-context/#traceparent-header
+	traceparentHeader = "traceparent"
+
+	// Header containing the W3C Trace Context tracestate, see
+	// https://www.w3.org/TR/trace-context/#tracestate-header
+	tracestateHeader = "tracestate"
+
+	// Header containing the absolute deadline for the call, as unix-nanos.
+	// Unlike _timeout, which is relative and reset at every hop, _deadline
+	// is set once and carried unchanged through Clone so a multi-hop call
+	// can't outlive the time budget the original caller intended.
+	deadlineHeader = "_deadline"
+
+	// Header containing the name of the method being called. Set by the
+	// generated client before a request is dispatched so interceptors (see
+	// Interceptor) and handlers can identify the call without threading the
+	// method name through separately.
+	methodHeader = "_method"
+
 	// Default request timeout
 	defaultTimeout = 5 * time.Second
+
+	// traceparentVersion is the only version of the traceparent header
+	// format frugal currently emits.
+	traceparentVersion = "00"
+
+	// traceparentSampledFlags marks a traceparent as sampled.
+	traceparentSampledFlags = "01"
 )
 
 // FContext is the context for a Frugal message. Every RPC has an FContext,
@@ -54,8 +86,9 @@ const (
 // FContext also plays a key role in Frugal's multiplexing support. A unique,
 // per-request operation ID is set on every FContext before a request is made.
 // This operation ID is sent in the request and included in the response, which
-// is then used to correlate a response to a request. The operation ID is an
-// internal implementation detail and is not exposed to the user.
+// is then used to correlate a response to a request. It's managed internally
+// by the multiplexing layer and should not be set directly, though it's
+// readable via OpID() for logging and diagnostics.
 //
 // An FContext should belong to a single request for the lifetime of that
 // request. It can be reused once the request has completed, though they should
@@ -70,9 +103,14 @@ type FContext interface {
 	// CorrelationID returns the correlation id for the context.
 	CorrelationID() string
 
+	// OpID returns the current operation id for the context, as a string.
+	// It's primarily useful for logging and diagnostics; multiplexing
+	// correlates requests to responses using this same value internally.
+	OpID() string
+
 	// AddRequestHeader adds a request header to the context for the given
-	// name. The headers _cid and _opid are reserved. Returns the same FContext
-	// to allow for chaining calls.
+	// name. The headers _cid, _opid, traceparent, and tracestate are reserved.
+	// Returns the same FContext to allow for chaining calls.
 	AddRequestHeader(name, value string) FContext
 
 	// RequestHeader gets the named request header.
@@ -98,6 +136,51 @@ type FContext interface {
 
 	// Timeout returns the request timeout.
 	Timeout() time.Duration
+
+	// TraceID returns the W3C Trace Context trace-id for the context. It's
+	// generated the first time an FContext is created and preserved across
+	// Clone, so every FContext derived from a single inbound request shares
+	// the same trace-id.
+	TraceID() string
+
+	// SpanID returns the W3C Trace Context span-id identifying this
+	// FContext's place in the trace.
+	SpanID() string
+
+	// ParentSpanID returns the span-id of the FContext this one was cloned
+	// or started from, or the empty string if this is a root FContext.
+	ParentSpanID() string
+
+	// StartSpan opens a span named name via the registered TracerProvider
+	// (see SetTracerProvider), advancing this FContext's span-id to a new
+	// child of its current one. It returns the FContext for chaining and a
+	// finish function that must be called with the RPC's error (nil on
+	// success) to end the span.
+	StartSpan(name string) (FContext, func(error))
+
+	// AddBaggage adds a baggage entry under the given key. Baggage is a
+	// propagated request header: unlike a plain request header added with
+	// AddRequestHeader, it's carried over to every downstream FContext
+	// created by Clone. Returns the same FContext to allow for chaining
+	// calls.
+	AddBaggage(key, value string) FContext
+
+	// Baggage returns a copy of the context's baggage.
+	Baggage() map[string]string
+
+	// SetMethod sets the name of the method being called. It's set by the
+	// generated client before a request is dispatched. Returns the same
+	// FContext to allow for chaining calls.
+	SetMethod(method string) FContext
+
+	// Method returns the name of the method being called, or the empty
+	// string if it hasn't been set.
+	Method() string
+
+	// Deadline returns the absolute time by which the call must complete
+	// and whether one has been set. It reflects the smaller of the local
+	// timeout and any inherited _deadline, consistent with Timeout.
+	Deadline() (time.Time, bool)
 }
 
 // FContextWithEphemeralProperties is an extension of the FContext interface
@@ -112,15 +195,37 @@ type FContextWithEphemeralProperties interface {
 	Clone() FContextWithEphemeralProperties
 
 	// EphemeralProperty gets the property associated with the given key.
+	//
+	// Deprecated: interface{} keys from different libraries can collide on
+	// the same value and must be type-asserted back by callers. Use
+	// PropertyKey instead.
 	EphemeralProperty(key interface{}) (interface{}, bool)
 
 	// EphemeralProperties returns a copy of the ephemeral properties map.
 	EphemeralProperties() map[interface{}]interface{}
 
 	// AddEphemeralProperty adds a keyp-value pair to the ephemeral properties.
+	//
+	// Deprecated: interface{} keys from different libraries can collide on
+	// the same value. Use PropertyKey instead.
 	AddEphemeralProperty(key, value interface{}) FContext
 }
 
+// FContextWithGoContext is an extension of the FContext interface that
+// exposes the context.Context the request arrived on, letting a server
+// handler observe caller cancellation (via ctx.Done()) rather than only the
+// timeout recorded in the FContext's headers.
+// TODO 4.0 add this to the FContext interface
+type FContextWithGoContext interface {
+	FContext
+
+	// Context returns the context.Context the request arrived on. FContexts
+	// built with FromContext, and ScrubHeaders copies of them, carry the
+	// context.Context they were built from; all others return
+	// context.Background().
+	Context() context.Context
+}
+
 // Clone performs a deep copy of an FContext while handling opids correctly.
 // TODO 4.0 consider adding this to the FContext interface.
 func Clone(ctx FContext) FContext {
@@ -129,15 +234,28 @@ func Clone(ctx FContext) FContext {
 	}
 
 	clone := &FContextImpl{
-		requestHeaders:      ctx.RequestHeaders(),
+		requestHeaders:      propagatedRequestHeaders(ctx.RequestHeaders()),
 		responseHeaders:     ctx.ResponseHeaders(),
 		ephemeralProperties: make(map[interface{}]interface{}),
 	}
 
+	establishDeadline(clone.requestHeaders, ctx.Timeout())
 	clone.requestHeaders[opIDHeader] = getNextOpID()
+	clone.parentSpanID = advanceTraceparent(clone.requestHeaders)
 	return clone
 }
 
+// establishDeadline sets _deadline to now+timeout unless headers already
+// carry one forward from a prior hop. Clone calls this with the source
+// FContext's current Timeout(), so a call's absolute budget is locked in at
+// the first hop it's forwarded through and can't be renegotiated by any
+// hop after that (see SetTimeout).
+func establishDeadline(headers map[string]string, timeout time.Duration) {
+	if _, ok := headers[deadlineHeader]; !ok {
+		headers[deadlineHeader] = strconv.FormatInt(time.Now().Add(timeout).UnixNano(), 10)
+	}
+}
+
 var nextOpID uint64
 
 func getNextOpID() string {
@@ -149,6 +267,9 @@ type FContextImpl struct {
 	requestHeaders      map[string]string
 	responseHeaders     map[string]string
 	ephemeralProperties map[interface{}]interface{}
+	parentSpanID        string
+	goCtx               context.Context
+	spanGoCtx           context.Context
 	mu                  sync.RWMutex
 }
 
@@ -169,7 +290,12 @@ func NewFContext(correlationID string) FContext {
 		responseHeaders:     make(map[string]string),
 		ephemeralProperties: make(map[interface{}]interface{}),
 	}
+	ctx.requestHeaders[traceparentHeader] = formatTraceparent(generateTraceID(), generateSpanID())
 
+	// _deadline is not set here. The first SetTimeout call on this FContext
+	// establishes one from the timeout it's given; if SetTimeout is never
+	// called, Clone locks one in from the current local timeout on its way
+	// out. Once set, later SetTimeout calls can't push it out.
 	return ctx
 }
 
@@ -180,9 +306,16 @@ func (c *FContextImpl) CorrelationID() string {
 	return c.requestHeaders[cidHeader]
 }
 
+// OpID returns the current operation id for the context, as a string.
+func (c *FContextImpl) OpID() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.requestHeaders[opIDHeader]
+}
+
 // AddRequestHeader adds a request header to the context for the given name.
-// The headers _cid and _opid are reserved. Returns the same FContext to allow
-// for chaining calls.
+// The headers _cid, _opid, traceparent, and tracestate are reserved. Returns
+// the same FContext to allow for chaining calls.
 func (c *FContextImpl) AddRequestHeader(name, value string) FContext {
 	c.mu.Lock()
 	c.requestHeaders[name] = value
@@ -238,36 +371,171 @@ func (c *FContextImpl) ResponseHeaders() map[string]string {
 	return headers
 }
 
-// SetTimeout sets the request timeout. Default is 5 seconds. Returns the same
-// FContext to allow for chaining calls.
+// SetTimeout sets the request timeout. Default is 5 seconds. Returns the
+// same FContext to allow for chaining calls.
+//
+// SetTimeout only ever changes the local, per-hop timeout. It never pushes
+// out an inherited _deadline: a hop that received a propagated deadline and
+// calls SetTimeout with a longer duration must not be able to extend the
+// call's overall time budget. The one exception is a fresh FContext that's
+// never been cloned and so has no _deadline at all yet; in that case
+// SetTimeout establishes one from now plus timeout, and any later
+// SetTimeout call on the same FContext can't extend it either.
 func (c *FContextImpl) SetTimeout(timeout time.Duration) FContext {
 	c.mu.Lock()
 	c.requestHeaders[timeoutHeader] = strconv.FormatInt(int64(timeout/time.Millisecond), 10)
+	if _, ok := c.requestHeaders[deadlineHeader]; !ok {
+		c.requestHeaders[deadlineHeader] = strconv.FormatInt(time.Now().Add(timeout).UnixNano(), 10)
+	}
 	c.mu.Unlock()
 	return c
 }
 
-// Timeout returns the request timeout.
+// Timeout returns the time remaining for the request: the smaller of the
+// local, per-hop timeout and the time remaining until the call's absolute
+// deadline (see _deadline), so a chain of calls can't keep resetting its
+// clock at every hop.
 func (c *FContextImpl) Timeout() time.Duration {
 	c.mu.RLock()
 	timeoutMillisStr := c.requestHeaders[timeoutHeader]
+	deadlineStr := c.requestHeaders[deadlineHeader]
 	c.mu.RUnlock()
-	timeoutMillis, err := strconv.ParseInt(timeoutMillisStr, 10, 64)
+
+	local := defaultTimeout
+	if timeoutMillis, err := strconv.ParseInt(timeoutMillisStr, 10, 64); err == nil {
+		local = time.Millisecond * time.Duration(timeoutMillis)
+	}
+
+	deadlineNanos, err := strconv.ParseInt(deadlineStr, 10, 64)
 	if err != nil {
-		return defaultTimeout
+		return local
+	}
+	if remaining := time.Until(time.Unix(0, deadlineNanos)); remaining < local {
+		return remaining
 	}
-	return time.Millisecond * time.Duration(timeoutMillis)
+	return local
+}
+
+// SetMethod sets the name of the method being called. Returns the same
+// FContext to allow for chaining calls.
+func (c *FContextImpl) SetMethod(method string) FContext {
+	return c.AddRequestHeader(methodHeader, method)
+}
+
+// Method returns the name of the method being called, or the empty string
+// if it hasn't been set.
+func (c *FContextImpl) Method() string {
+	method, _ := c.RequestHeader(methodHeader)
+	return method
+}
+
+// Deadline returns the absolute time by which the call must complete and
+// whether one has been set.
+func (c *FContextImpl) Deadline() (time.Time, bool) {
+	if timeout := c.Timeout(); timeout > 0 {
+		return time.Now().Add(timeout), true
+	}
+	return time.Time{}, false
+}
+
+// TraceID returns the W3C Trace Context trace-id for the context.
+func (c *FContextImpl) TraceID() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	traceID, _, _ := parseTraceparent(c.requestHeaders[traceparentHeader])
+	return traceID
+}
+
+// SpanID returns the W3C Trace Context span-id identifying this FContext's
+// place in the trace.
+func (c *FContextImpl) SpanID() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, spanID, _ := parseTraceparent(c.requestHeaders[traceparentHeader])
+	return spanID
+}
+
+// ParentSpanID returns the span-id of the FContext this one was cloned or
+// started from, or the empty string if this is a root FContext.
+func (c *FContextImpl) ParentSpanID() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.parentSpanID
+}
+
+// StartSpan opens a span named name via the registered TracerProvider,
+// advancing this FContext's span-id to a new child of its current one. It
+// returns the FContext for chaining and a finish function that must be
+// called with the RPC's error (nil on success) to end the span.
+//
+// With a real TracerProvider registered (see SetTracerProvider, and
+// tracing/otel for an OpenTelemetry-backed one), this opens an actual span
+// and records it on the FContext so a later ToContext call hands downstream
+// thrift handlers a context.Context that otel-instrumented code recognizes.
+func (c *FContextImpl) StartSpan(name string) (FContext, func(error)) {
+	c.mu.Lock()
+	parentSpanID := advanceTraceparent(c.requestHeaders)
+	c.parentSpanID = parentSpanID
+	traceID, spanID, _ := parseTraceparent(c.requestHeaders[traceparentHeader])
+	c.mu.Unlock()
+
+	tracerProviderMu.RLock()
+	tp := tracerProvider
+	tracerProviderMu.RUnlock()
+
+	c.mu.RLock()
+	base := c.goCtx
+	c.mu.RUnlock()
+	if base == nil {
+		base = context.Background()
+	}
+
+	spanCtx, span := tp.Tracer("frugal").Start(base, name, tracing.SpanContext{
+		TraceID:      traceID,
+		SpanID:       spanID,
+		ParentSpanID: parentSpanID,
+	})
+
+	c.mu.Lock()
+	c.spanGoCtx = spanCtx
+	// The TracerProvider may have minted its own span-id rather than using
+	// the one frugal pre-generated above (OTel's SDK always does); when it
+	// does, reconcile so the traceparent serialized to the wire - and
+	// TraceID()/SpanID() - reference the span the provider's exporter
+	// actually recorded, not an id it never saw.
+	if realSpanID := span.SpanID(); realSpanID != "" && realSpanID != spanID {
+		c.requestHeaders[traceparentHeader] = formatTraceparent(traceID, realSpanID)
+	}
+	c.mu.Unlock()
+
+	return c, func(err error) {
+		if err != nil {
+			span.SetError(err)
+		}
+		span.End()
+	}
+}
+
+// spanContext returns the context.Context StartSpan's TracerProvider handed
+// back, which carries the real span it opened, or nil if StartSpan hasn't
+// been called.
+func (c *FContextImpl) spanContext() context.Context {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.spanGoCtx
 }
 
 // Clone performs a deep copy of an FContextWithEphemeralProperties while
 // handling opids correctly.
 func (c *FContextImpl) Clone() FContextWithEphemeralProperties {
 	cloned := &FContextImpl{
-		requestHeaders:      c.RequestHeaders(),
+		requestHeaders:      propagatedRequestHeaders(c.RequestHeaders()),
 		responseHeaders:     c.ResponseHeaders(),
 		ephemeralProperties: c.EphemeralProperties(),
 	}
+	establishDeadline(cloned.requestHeaders, c.Timeout())
 	cloned.requestHeaders[opIDHeader] = getNextOpID()
+	cloned.parentSpanID = advanceTraceparent(cloned.requestHeaders)
 	return cloned
 }
 
@@ -298,6 +566,18 @@ func (c *FContextImpl) AddEphemeralProperty(key, value interface{}) FContext {
 	return c
 }
 
+// Context returns the context.Context the request arrived on, or
+// context.Background() if this FContext wasn't built with FromContext (or
+// scrubbed by ScrubHeaders from one that was).
+func (c *FContextImpl) Context() context.Context {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.goCtx == nil {
+		return context.Background()
+	}
+	return c.goCtx
+}
+
 // setRequestOpID sets the request operation id for context.
 func setRequestOpID(ctx FContext, id uint64) {
 	opIDStr := strconv.FormatUint(id, 10)
@@ -331,11 +611,169 @@ var generateCorrelationID = func() string {
 	return nuid.Next()
 }
 
-// ToContext converts a FContext to a context.Context for integration with thrift.
+// tracerProvider is the TracerProvider used by FContext.StartSpan. It
+// defaults to a no-op implementation until SetTracerProvider is called.
+var (
+	tracerProviderMu sync.RWMutex
+	tracerProvider   tracing.TracerProvider = tracing.NoopTracerProvider
+)
+
+// SetTracerProvider registers the TracerProvider used by FContext.StartSpan
+// to create spans. It should be called once, during application startup,
+// before any FContexts start spans. Passing nil restores the no-op default.
+func SetTracerProvider(tp tracing.TracerProvider) {
+	if tp == nil {
+		tp = tracing.NoopTracerProvider
+	}
+	tracerProviderMu.Lock()
+	tracerProvider = tp
+	tracerProviderMu.Unlock()
+}
+
+// generateTraceID returns a random 32 hex character W3C trace-id.
+func generateTraceID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// generateSpanID returns a random 16 hex character W3C span-id.
+func generateSpanID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// formatTraceparent builds a traceparent header value from a trace-id and
+// span-id, per https://www.w3.org/TR/trace-context/#traceparent-header.
+func formatTraceparent(traceID, spanID string) string {
+	return traceparentVersion + "-" + traceID + "-" + spanID + "-" + traceparentSampledFlags
+}
+
+// parseTraceparent extracts the trace-id and span-id from a traceparent
+// header value. ok is false if value isn't a well-formed traceparent.
+func parseTraceparent(value string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(value, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// advanceTraceparent replaces the traceparent in headers with a new span-id
+// that's a child of the current one, preserving the trace-id. It returns the
+// span-id that was current before the call (the new traceparent's parent),
+// generating a fresh trace-id if headers didn't already have a valid one.
+func advanceTraceparent(headers map[string]string) (parentSpanID string) {
+	traceID, parentSpanID, ok := parseTraceparent(headers[traceparentHeader])
+	if !ok {
+		traceID = generateTraceID()
+		parentSpanID = ""
+	}
+	headers[traceparentHeader] = formatTraceparent(traceID, generateSpanID())
+	return parentSpanID
+}
+
+// correlationIDContextKeyType is the well-known context.Context key under
+// which FromContext looks for a pre-existing correlation id.
+type correlationIDContextKeyType struct{}
+
+var correlationIDContextKey = correlationIDContextKeyType{}
+
+// WithCorrelationID returns a context.Context carrying correlationID so a
+// later FromContext call on it (or a descendant) reuses it instead of
+// generating a new one.
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDContextKey, correlationID)
+}
+
+// contextHeaderKeys maps request header names to the context.Context key
+// FromContext should read their value from, for out-of-band propagation of
+// values a caller stashed on a context.Context before an FContext existed.
+var (
+	contextHeaderKeysMu sync.RWMutex
+	contextHeaderKeys   = make(map[string]interface{})
+)
+
+// RegisterContextHeaderKey arranges for FromContext to populate the request
+// header named header from ctx.Value(key) whenever that value is a string.
+func RegisterContextHeaderKey(header string, key interface{}) {
+	contextHeaderKeysMu.Lock()
+	contextHeaderKeys[header] = key
+	contextHeaderKeysMu.Unlock()
+}
+
+// FromContext builds an FContext from an incoming context.Context, seeding
+// it with the context's deadline and correlation id (see WithCorrelationID)
+// and recording ctx itself so server handlers can observe caller
+// cancellation via the FContextWithGoContext extension. It's the inverse of
+// ToContext, for services that receive a context.Context before an FContext
+// exists, e.g. at a gRPC or HTTP gateway in front of a Frugal service.
+func FromContext(ctx context.Context) FContext {
+	correlationID, _ := ctx.Value(correlationIDContextKey).(string)
+	fctx := NewFContext(correlationID).(*FContextImpl)
+
+	fctx.mu.Lock()
+	fctx.goCtx = ctx
+	if deadline, ok := ctx.Deadline(); ok {
+		fctx.requestHeaders[timeoutHeader] = strconv.FormatInt(int64(time.Until(deadline)/time.Millisecond), 10)
+		fctx.requestHeaders[deadlineHeader] = strconv.FormatInt(deadline.UnixNano(), 10)
+	}
+	fctx.mu.Unlock()
+
+	contextHeaderKeysMu.RLock()
+	for header, key := range contextHeaderKeys {
+		if value, ok := ctx.Value(key).(string); ok {
+			fctx.AddRequestHeader(header, value)
+		}
+	}
+	contextHeaderKeysMu.RUnlock()
+
+	return fctx
+}
+
+// ToContext converts a FContext to a context.Context for integration with
+// thrift. If fctx carries the context.Context it arrived on (see
+// FContextWithGoContext, populated by FromContext), the returned
+// context.Context derives from it, so a caller's cancellation (e.g. a
+// disconnected client) propagates through to the handler. If fctx's
+// StartSpan was called with a real TracerProvider registered (see
+// SetTracerProvider, and tracing/otel for an OpenTelemetry-backed one), the
+// returned context.Context carries that real span, so otel-instrumented
+// downstream code sees it via trace.SpanFromContext.
 func ToContext(fctx FContext) (context.Context, context.CancelFunc) {
 	ctx := context.Background()
+	if fcwgc, ok := fctx.(FContextWithGoContext); ok {
+		ctx = fcwgc.Context()
+	}
+	if swsc, ok := fctx.(interface{ spanContext() context.Context }); ok {
+		if sc := swsc.spanContext(); sc != nil {
+			ctx = sc
+		}
+	}
+	if traceID, spanID, ok := parseTraceparent(fctx.RequestHeaders()[traceparentHeader]); ok {
+		ctx = context.WithValue(ctx, traceSpanContextKey, tracing.SpanContext{
+			TraceID:      traceID,
+			SpanID:       spanID,
+			ParentSpanID: fctx.ParentSpanID(),
+		})
+	}
 	if to := fctx.Timeout(); to > 0 {
 		return context.WithTimeout(ctx, to)
 	}
 	return ctx, func() {}
 }
+
+// traceSpanContextKeyType is an unexported type for the context.Context key
+// under which ToContext stores the current tracing.SpanContext, preventing
+// collisions with keys defined in other packages.
+type traceSpanContextKeyType struct{}
+
+var traceSpanContextKey = traceSpanContextKeyType{}
+
+// SpanContextFromContext returns the tracing.SpanContext that ToContext
+// attached to ctx, if any.
+func SpanContextFromContext(ctx context.Context) (tracing.SpanContext, bool) {
+	sc, ok := ctx.Value(traceSpanContextKey).(tracing.SpanContext)
+	return sc, ok
+}
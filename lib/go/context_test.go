@@ -0,0 +1,97 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestSetTimeoutDoesNotExtendInheritedDeadline guards against a multi-hop
+// clock reset: a hop that inherits a deadline via Clone and then calls
+// SetTimeout with a longer duration must still be capped by the inherited
+// deadline, not get a fresh one starting from now().
+func TestSetTimeoutDoesNotExtendInheritedDeadline(t *testing.T) {
+	parent := NewFContext("")
+	parent.SetTimeout(50 * time.Millisecond)
+
+	child := Clone(parent)
+	child.SetTimeout(100 * time.Millisecond)
+
+	if remaining := child.Timeout(); remaining > 60*time.Millisecond {
+		t.Fatalf("expected child timeout to stay capped near the inherited ~50ms deadline, got %s", remaining)
+	}
+}
+
+// TestSetTimeoutEstablishesDeadlineWhenAbsent covers the case where an
+// FContext has no _deadline yet, e.g. one decoded from a peer that doesn't
+// send the header: SetTimeout should still establish one.
+func TestSetTimeoutEstablishesDeadlineWhenAbsent(t *testing.T) {
+	ctx := &FContextImpl{
+		requestHeaders:      map[string]string{cidHeader: "cid"},
+		responseHeaders:     make(map[string]string),
+		ephemeralProperties: make(map[interface{}]interface{}),
+	}
+
+	ctx.SetTimeout(100 * time.Millisecond)
+
+	if _, ok := ctx.RequestHeader(deadlineHeader); !ok {
+		t.Fatal("expected SetTimeout to establish a _deadline when none was present")
+	}
+	if remaining := ctx.Timeout(); remaining <= 0 || remaining > 100*time.Millisecond {
+		t.Fatalf("expected timeout close to 100ms, got %s", remaining)
+	}
+}
+
+// TestCloneEstablishesDeadlineFromLocalTimeout covers the case SetTimeout no
+// longer handles on its own: a parent that's never had its timeout changed
+// (and so carries no _deadline at all, since NewFContext doesn't set one)
+// must still get one locked in the moment it's first cloned for an outbound
+// call, based on its local timeout at that instant.
+func TestCloneEstablishesDeadlineFromLocalTimeout(t *testing.T) {
+	parent := NewFContext("")
+	if _, ok := parent.RequestHeader(deadlineHeader); ok {
+		t.Fatal("expected a freshly-created FContext to have no _deadline yet")
+	}
+
+	child := Clone(parent)
+	if _, ok := child.RequestHeader(deadlineHeader); !ok {
+		t.Fatal("expected Clone to establish a _deadline from the parent's local timeout")
+	}
+	if remaining := child.Timeout(); remaining <= 0 || remaining > defaultTimeout {
+		t.Fatalf("expected timeout close to the default %s, got %s", defaultTimeout, remaining)
+	}
+}
+
+// TestToContextPropagatesCancellationFromFromContext guards the documented
+// server conversion path, FromContext followed by ToContext: cancelling the
+// context.Context a request arrived on (e.g. because the client
+// disconnected) must be observable on the context.Context ToContext hands to
+// the handler, not just on the original one.
+func TestToContextPropagatesCancellationFromFromContext(t *testing.T) {
+	goCtx, cancel := context.WithCancel(context.Background())
+	fctx := FromContext(goCtx)
+
+	handlerCtx, finish := ToContext(fctx)
+	defer finish()
+
+	cancel()
+
+	select {
+	case <-handlerCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected cancelling the source context to cancel ToContext's result")
+	}
+}
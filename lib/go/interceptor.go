@@ -0,0 +1,43 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+// Handler invokes a single RPC: method identifies the call, req is the
+// generated arguments struct, and resp is the generated, pre-allocated
+// result struct that the handler mutates in place. This is the shape of
+// both a generated processor's dispatch function and of the "next" step an
+// Interceptor wraps.
+type Handler func(ctx FContext, method string, req, resp interface{}) error
+
+// Interceptor wraps a Handler to add cross-cutting behavior (metrics,
+// logging, panic recovery, rate limiting, and the like) around every RPC a
+// client makes or a server processes, without the generated code needing to
+// know about it. Call next to invoke the next interceptor in the chain, or
+// the terminal Handler if this is the last one.
+type Interceptor func(ctx FContext, method string, req, resp interface{}, next Handler) error
+
+// FServiceMiddlewareChain wraps handler with interceptors and returns the
+// resulting Handler. Interceptors run in the order given: interceptors[0]
+// is outermost, so it sees a request first and a response (or error) last.
+func FServiceMiddlewareChain(handler Handler, interceptors ...Interceptor) Handler {
+	chained := handler
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor := interceptors[i]
+		next := chained
+		chained = func(ctx FContext, method string, req, resp interface{}) error {
+			return interceptor(ctx, method, req, resp, next)
+		}
+	}
+	return chained
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestFServiceMiddlewareChainOrdersInterceptors guards the chain's
+// documented ordering: interceptors[0] must see a request first and a
+// response (or error) last, with each interceptor free to observe or
+// modify what runs around it.
+func TestFServiceMiddlewareChainOrdersInterceptors(t *testing.T) {
+	var before, after []string
+
+	record := func(name string) Interceptor {
+		return func(ctx FContext, method string, req, resp interface{}, next Handler) error {
+			before = append(before, name)
+			err := next(ctx, method, req, resp)
+			after = append(after, name)
+			return err
+		}
+	}
+
+	handler := func(ctx FContext, method string, req, resp interface{}) error { return nil }
+	chained := FServiceMiddlewareChain(handler, record("first"), record("second"), record("third"))
+
+	if err := chained(NewFContext(""), "ping", nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantBefore := []string{"first", "second", "third"}
+	wantAfter := []string{"third", "second", "first"}
+	if !equalStrings(before, wantBefore) {
+		t.Fatalf("expected interceptors to run before next in order %v, got %v", wantBefore, before)
+	}
+	if !equalStrings(after, wantAfter) {
+		t.Fatalf("expected interceptors to run after next in reverse order %v, got %v", wantAfter, after)
+	}
+}
+
+// TestFServiceMiddlewareChainShortCircuits guards the chain's other
+// documented behavior: an interceptor that returns without calling next
+// must prevent every interceptor after it, and the terminal Handler, from
+// running at all.
+func TestFServiceMiddlewareChainShortCircuits(t *testing.T) {
+	var ran []string
+	wantErr := errors.New("denied")
+
+	record := func(name string) Interceptor {
+		return func(ctx FContext, method string, req, resp interface{}, next Handler) error {
+			ran = append(ran, name)
+			return next(ctx, method, req, resp)
+		}
+	}
+	deny := func(ctx FContext, method string, req, resp interface{}, next Handler) error {
+		ran = append(ran, "deny")
+		return wantErr
+	}
+
+	handler := func(ctx FContext, method string, req, resp interface{}) error {
+		ran = append(ran, "handler")
+		return nil
+	}
+	chained := FServiceMiddlewareChain(handler, record("first"), deny, record("third"))
+
+	err := chained(NewFContext(""), "ping", nil, nil)
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("expected the denying interceptor's error to propagate, got %v", err)
+	}
+
+	want := []string{"first", "deny"}
+	if !equalStrings(ran, want) {
+		t.Fatalf("expected only %v to run, got %v", want, ran)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package middleware
+
+import (
+	"log"
+
+	frugal "github.com/Workiva/frugal/lib/go"
+)
+
+// Logger logs a single structured request event. Implementations adapt a
+// concrete structured logging library.
+type Logger interface {
+	Log(fields map[string]interface{})
+}
+
+// stdLogger adapts the standard library's log package as a Logger.
+type stdLogger struct{ *log.Logger }
+
+func (l stdLogger) Log(fields map[string]interface{}) {
+	l.Printf("%v", fields)
+}
+
+// NewLoggingInterceptor returns a frugal.Interceptor that logs each request
+// with its correlation id, op id, and method, plus the error if one
+// occurred. A nil logger logs via the standard library's default logger.
+func NewLoggingInterceptor(logger Logger) frugal.Interceptor {
+	if logger == nil {
+		logger = stdLogger{log.Default()}
+	}
+	return func(ctx frugal.FContext, method string, req, resp interface{}, next frugal.Handler) error {
+		err := next(ctx, method, req, resp)
+
+		fields := map[string]interface{}{
+			"cid":    ctx.CorrelationID(),
+			"opid":   ctx.OpID(),
+			"method": method,
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+		}
+		logger.Log(fields)
+
+		return err
+	}
+}
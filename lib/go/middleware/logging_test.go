@@ -0,0 +1,45 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package middleware
+
+import (
+	"testing"
+
+	frugal "github.com/Workiva/frugal/lib/go"
+)
+
+type fakeLogger struct {
+	fields map[string]interface{}
+}
+
+func (f *fakeLogger) Log(fields map[string]interface{}) {
+	f.fields = fields
+}
+
+func TestLoggingInterceptorLogsOpID(t *testing.T) {
+	logger := &fakeLogger{}
+	interceptor := NewLoggingInterceptor(logger)
+
+	ctx := frugal.NewFContext("")
+	handler := func(ctx frugal.FContext, method string, req, resp interface{}) error { return nil }
+
+	if err := interceptor(ctx, "ping", nil, nil, handler); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	opid, ok := logger.fields["opid"].(string)
+	if !ok || opid == "" || opid != ctx.OpID() {
+		t.Fatalf("expected logged opid field to equal ctx.OpID() (%q), got %v", ctx.OpID(), logger.fields["opid"])
+	}
+}
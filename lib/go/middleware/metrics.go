@@ -0,0 +1,46 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package middleware provides a small set of frugal.Interceptors for
+// cross-cutting concerns common to every RPC: metrics, structured logging,
+// panic recovery, and rate limiting.
+package middleware
+
+import (
+	"time"
+
+	frugal "github.com/Workiva/frugal/lib/go"
+)
+
+// MetricsSink receives per-method request counts and latency observations.
+// Implementations adapt a concrete metrics backend; see the
+// middleware/prometheus subpackage for a Prometheus-backed one.
+type MetricsSink interface {
+	// IncRequestCount records one completed request for method.
+	IncRequestCount(method string)
+
+	// ObserveLatency records how long a request for method took.
+	ObserveLatency(method string, d time.Duration)
+}
+
+// NewMetricsInterceptor returns a frugal.Interceptor that records a request
+// count and latency observation per method to sink.
+func NewMetricsInterceptor(sink MetricsSink) frugal.Interceptor {
+	return func(ctx frugal.FContext, method string, req, resp interface{}, next frugal.Handler) error {
+		start := time.Now()
+		err := next(ctx, method, req, resp)
+		sink.IncRequestCount(method)
+		sink.ObserveLatency(method, time.Since(start))
+		return err
+	}
+}
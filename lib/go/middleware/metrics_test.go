@@ -0,0 +1,86 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package middleware
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	frugal "github.com/Workiva/frugal/lib/go"
+)
+
+type fakeMetricsSink struct {
+	counts     map[string]int
+	observed   map[string]time.Duration
+	observedOK bool
+}
+
+func (f *fakeMetricsSink) IncRequestCount(method string) {
+	if f.counts == nil {
+		f.counts = make(map[string]int)
+	}
+	f.counts[method]++
+}
+
+func (f *fakeMetricsSink) ObserveLatency(method string, d time.Duration) {
+	if f.observed == nil {
+		f.observed = make(map[string]time.Duration)
+	}
+	f.observed[method] = d
+	f.observedOK = true
+}
+
+// TestMetricsInterceptorRecordsCountAndLatency guards the interceptor's
+// basic contract on the success path: it must record one request count and
+// a latency observation for the method, and still return next's result.
+func TestMetricsInterceptorRecordsCountAndLatency(t *testing.T) {
+	sink := &fakeMetricsSink{}
+	interceptor := NewMetricsInterceptor(sink)
+
+	handler := func(ctx frugal.FContext, method string, req, resp interface{}) error {
+		return nil
+	}
+
+	if err := interceptor(frugal.NewFContext(""), "ping", nil, nil, handler); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sink.counts["ping"] != 1 {
+		t.Fatalf("expected one recorded request count for %q, got %d", "ping", sink.counts["ping"])
+	}
+	if !sink.observedOK {
+		t.Fatal("expected a latency observation to be recorded")
+	}
+}
+
+// TestMetricsInterceptorRecordsOnError guards against the interceptor
+// skipping its recording when the handler fails: metrics need failed calls
+// counted too, and the handler's error must still propagate.
+func TestMetricsInterceptorRecordsOnError(t *testing.T) {
+	sink := &fakeMetricsSink{}
+	interceptor := NewMetricsInterceptor(sink)
+	wantErr := errors.New("boom")
+
+	handler := func(ctx frugal.FContext, method string, req, resp interface{}) error {
+		return wantErr
+	}
+
+	err := interceptor(frugal.NewFContext(""), "ping", nil, nil, handler)
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("expected the handler's error to propagate, got %v", err)
+	}
+	if sink.counts["ping"] != 1 {
+		t.Fatalf("expected a request count to be recorded even on error, got %d", sink.counts["ping"])
+	}
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package prometheus adapts middleware.MetricsSink to Prometheus client
+// metrics.
+package prometheus
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/Workiva/frugal/lib/go/middleware"
+)
+
+// Sink is a middleware.MetricsSink backed by a Prometheus CounterVec and
+// HistogramVec, both labeled by method.
+type Sink struct {
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+}
+
+// NewSink creates a Sink and registers its metrics with reg. Use
+// prometheus.DefaultRegisterer for the global registry.
+func NewSink(reg prometheus.Registerer) *Sink {
+	s := &Sink{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "frugal_requests_total",
+			Help: "Total number of Frugal RPCs processed, by method.",
+		}, []string{"method"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "frugal_request_latency_seconds",
+			Help: "Frugal RPC latency in seconds, by method.",
+		}, []string{"method"}),
+	}
+	reg.MustRegister(s.requests, s.latency)
+	return s
+}
+
+// IncRequestCount implements middleware.MetricsSink.
+func (s *Sink) IncRequestCount(method string) {
+	s.requests.WithLabelValues(method).Inc()
+}
+
+// ObserveLatency implements middleware.MetricsSink.
+func (s *Sink) ObserveLatency(method string, d time.Duration) {
+	s.latency.WithLabelValues(method).Observe(d.Seconds())
+}
+
+var _ middleware.MetricsSink = (*Sink)(nil)
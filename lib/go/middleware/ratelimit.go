@@ -0,0 +1,86 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package middleware
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	frugal "github.com/Workiva/frugal/lib/go"
+)
+
+// TokenBucket is a simple per-key token bucket rate limiter.
+type TokenBucket struct {
+	rate  float64 // tokens added per second
+	burst float64 // bucket capacity
+
+	mu      sync.Mutex
+	buckets map[string]*bucketState
+}
+
+type bucketState struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// NewTokenBucket returns a TokenBucket that allows up to burst calls for a
+// given key before throttling it down to rate calls per second.
+func NewTokenBucket(rate, burst float64) *TokenBucket {
+	return &TokenBucket{
+		rate:    rate,
+		burst:   burst,
+		buckets: make(map[string]*bucketState),
+	}
+}
+
+// Allow reports whether a call keyed by key may proceed, consuming a token
+// if so.
+func (b *TokenBucket) Allow(key string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	state, ok := b.buckets[key]
+	if !ok {
+		state = &bucketState{tokens: b.burst, lastSeen: now}
+		b.buckets[key] = state
+	}
+
+	elapsed := now.Sub(state.lastSeen).Seconds()
+	state.lastSeen = now
+	state.tokens += elapsed * b.rate
+	if state.tokens > b.burst {
+		state.tokens = b.burst
+	}
+
+	if state.tokens < 1 {
+		return false
+	}
+	state.tokens--
+	return true
+}
+
+// NewRateLimitInterceptor returns a frugal.Interceptor that rejects a
+// request once the caller identified by the named request header (e.g. a
+// caller-id header) has exhausted its token bucket.
+func NewRateLimitInterceptor(bucket *TokenBucket, callerHeader string) frugal.Interceptor {
+	return func(ctx frugal.FContext, method string, req, resp interface{}, next frugal.Handler) error {
+		caller, _ := ctx.RequestHeader(callerHeader)
+		if !bucket.Allow(caller) {
+			return fmt.Errorf("frugal: rate limit exceeded for caller %q calling %q", caller, method)
+		}
+		return next(ctx, method, req, resp)
+	}
+}
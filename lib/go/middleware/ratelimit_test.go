@@ -0,0 +1,81 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package middleware
+
+import (
+	"testing"
+
+	frugal "github.com/Workiva/frugal/lib/go"
+)
+
+// TestTokenBucketAllowsUpToBurstThenThrottles guards the bucket's core
+// contract: a key gets burst calls for free, and the next one is rejected
+// until the rate replenishes a token.
+func TestTokenBucketAllowsUpToBurstThenThrottles(t *testing.T) {
+	bucket := NewTokenBucket(1, 3)
+
+	for i := 0; i < 3; i++ {
+		if !bucket.Allow("caller") {
+			t.Fatalf("expected call %d to be allowed within burst", i+1)
+		}
+	}
+	if bucket.Allow("caller") {
+		t.Fatal("expected the call beyond burst to be throttled")
+	}
+}
+
+// TestTokenBucketKeysAreIndependent guards against one caller's usage
+// throttling another: each key should get its own bucket.
+func TestTokenBucketKeysAreIndependent(t *testing.T) {
+	bucket := NewTokenBucket(1, 1)
+
+	if !bucket.Allow("a") {
+		t.Fatal("expected the first call for caller a to be allowed")
+	}
+	if !bucket.Allow("b") {
+		t.Fatal("expected caller b's bucket to be unaffected by caller a's usage")
+	}
+}
+
+// TestRateLimitInterceptorRejectsExhaustedCaller guards the interceptor
+// wiring around TokenBucket: once a caller's bucket is exhausted, the
+// interceptor must return an error instead of invoking next.
+func TestRateLimitInterceptorRejectsExhaustedCaller(t *testing.T) {
+	bucket := NewTokenBucket(1, 1)
+	interceptor := NewRateLimitInterceptor(bucket, "caller-id")
+
+	called := false
+	handler := func(ctx frugal.FContext, method string, req, resp interface{}) error {
+		called = true
+		return nil
+	}
+
+	ctx := frugal.NewFContext("")
+	ctx.AddRequestHeader("caller-id", "svc-a")
+
+	if err := interceptor(ctx, "ping", nil, nil, handler); err != nil {
+		t.Fatalf("unexpected error on the first call: %v", err)
+	}
+	if !called {
+		t.Fatal("expected the first call to reach the handler")
+	}
+
+	called = false
+	if err := interceptor(ctx, "ping", nil, nil, handler); err == nil {
+		t.Fatal("expected the second call to be rejected once the bucket is exhausted")
+	}
+	if called {
+		t.Fatal("expected next not to run once the bucket rejects the call")
+	}
+}
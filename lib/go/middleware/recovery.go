@@ -0,0 +1,43 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/apache/thrift/lib/go/thrift"
+
+	frugal "github.com/Workiva/frugal/lib/go"
+)
+
+// NewRecoveryInterceptor returns a frugal.Interceptor that recovers a panic
+// from the rest of the chain and converts it to a thrift.TApplicationException
+// with type INTERNAL_ERROR, so a single bad handler can't take down the
+// server and callers that type-switch on application exceptions (as
+// generated Frugal clients do) still get a meaningful result. It should be
+// registered first (see frugal.FServiceMiddlewareChain) so it wraps every
+// other interceptor.
+func NewRecoveryInterceptor() frugal.Interceptor {
+	return func(ctx frugal.FContext, method string, req, resp interface{}, next frugal.Handler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = thrift.NewTApplicationException(
+					thrift.INTERNAL_ERROR,
+					fmt.Sprintf("frugal: panic in handler for method %q: %v", method, r),
+				)
+			}
+		}()
+		return next(ctx, method, req, resp)
+	}
+}
@@ -0,0 +1,43 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package middleware
+
+import (
+	"testing"
+
+	"github.com/apache/thrift/lib/go/thrift"
+
+	frugal "github.com/Workiva/frugal/lib/go"
+)
+
+func TestRecoveryInterceptorReturnsApplicationException(t *testing.T) {
+	interceptor := NewRecoveryInterceptor()
+	ctx := frugal.NewFContext("")
+	handler := func(ctx frugal.FContext, method string, req, resp interface{}) error {
+		panic("boom")
+	}
+
+	err := interceptor(ctx, "ping", nil, nil, handler)
+	if err == nil {
+		t.Fatal("expected an error after a recovered panic")
+	}
+
+	appErr, ok := err.(thrift.TApplicationException)
+	if !ok {
+		t.Fatalf("expected a thrift.TApplicationException, got %T", err)
+	}
+	if appErr.TypeId() != thrift.INTERNAL_ERROR {
+		t.Fatalf("expected type INTERNAL_ERROR, got %v", appErr.TypeId())
+	}
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+// propertyKeyMarker is the unexported pointer-identity backing a
+// PropertyKey's actual ephemeral-properties map key. Two PropertyKeys are
+// never equal, even if constructed with the same name, since each gets its
+// own marker; this is what prevents unrelated libraries from colliding on
+// the same key.
+type propertyKeyMarker struct {
+	name string
+}
+
+// untypedPropertyKey lets WithEphemeralProperties set a PropertyKey[T]'s
+// value without knowing T at compile time.
+type untypedPropertyKey interface {
+	setUntyped(ctx FContext, value interface{}) bool
+}
+
+// PropertyKey is a typed, collision-proof key for an FContext's ephemeral
+// properties, replacing the older interface{}-keyed
+// AddEphemeralProperty/EphemeralProperty pair. Create one with
+// NewPropertyKey and share it as a package-level var.
+type PropertyKey[T any] struct {
+	marker *propertyKeyMarker
+}
+
+// NewPropertyKey creates a new PropertyKey. name is used only for
+// diagnostics; it has no bearing on the key's identity or equality.
+func NewPropertyKey[T any](name string) PropertyKey[T] {
+	return PropertyKey[T]{marker: &propertyKeyMarker{name: name}}
+}
+
+// Set stores v under k on ctx's ephemeral properties.
+func (k PropertyKey[T]) Set(ctx FContext, v T) {
+	if fctxEP, ok := ctx.(FContextWithEphemeralProperties); ok {
+		fctxEP.AddEphemeralProperty(k.marker, v)
+	}
+}
+
+// Get retrieves the value stored under k on ctx, if any. It returns false
+// if nothing has been set, or if ctx doesn't support ephemeral properties.
+func (k PropertyKey[T]) Get(ctx FContext) (T, bool) {
+	var zero T
+	fctxEP, ok := ctx.(FContextWithEphemeralProperties)
+	if !ok {
+		return zero, false
+	}
+	value, ok := fctxEP.EphemeralProperty(k.marker)
+	if !ok {
+		return zero, false
+	}
+	typed, ok := value.(T)
+	if !ok {
+		return zero, false
+	}
+	return typed, true
+}
+
+func (k PropertyKey[T]) setUntyped(ctx FContext, value interface{}) bool {
+	typed, ok := value.(T)
+	if !ok {
+		return false
+	}
+	k.Set(ctx, typed)
+	return true
+}
+
+// WithEphemeralProperties clones parent and sets each key/value pair on the
+// clone, for ergonomic chaining: pairs must alternate a PropertyKey[T] and
+// a value assignable to its T. A pair whose value doesn't match its key's
+// type is skipped.
+func WithEphemeralProperties(parent FContext, pairs ...interface{}) FContext {
+	ctx := Clone(parent)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if key, ok := pairs[i].(untypedPropertyKey); ok {
+			key.setUntyped(ctx, pairs[i+1])
+		}
+	}
+	return ctx
+}
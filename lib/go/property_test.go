@@ -0,0 +1,72 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import "testing"
+
+func TestPropertyKeySetGet(t *testing.T) {
+	key := NewPropertyKey[string]("test-key")
+	ctx := NewFContext("")
+
+	if _, ok := key.Get(ctx); ok {
+		t.Fatal("expected no value before Set")
+	}
+
+	key.Set(ctx, "hello")
+
+	v, ok := key.Get(ctx)
+	if !ok || v != "hello" {
+		t.Fatalf("expected (\"hello\", true), got (%q, %v)", v, ok)
+	}
+}
+
+func TestPropertyKeysWithSameNameDoNotCollide(t *testing.T) {
+	keyA := NewPropertyKey[string]("shared-name")
+	keyB := NewPropertyKey[string]("shared-name")
+	ctx := NewFContext("")
+
+	keyA.Set(ctx, "from-a")
+
+	if _, ok := keyB.Get(ctx); ok {
+		t.Fatal("expected keyB to be unaffected by keyA.Set despite sharing a name")
+	}
+	if v, _ := keyA.Get(ctx); v != "from-a" {
+		t.Fatalf("expected keyA's own value to be unaffected, got %q", v)
+	}
+}
+
+func TestWithEphemeralProperties(t *testing.T) {
+	key := NewPropertyKey[int]("count")
+	parent := NewFContext("")
+
+	child := WithEphemeralProperties(parent, key, 42)
+
+	if v, ok := key.Get(child); !ok || v != 42 {
+		t.Fatalf("expected (42, true) on child, got (%v, %v)", v, ok)
+	}
+	if _, ok := key.Get(parent); ok {
+		t.Fatal("expected parent to be unaffected by WithEphemeralProperties")
+	}
+}
+
+func TestWithEphemeralPropertiesSkipsMismatchedType(t *testing.T) {
+	key := NewPropertyKey[int]("count")
+	parent := NewFContext("")
+
+	child := WithEphemeralProperties(parent, key, "not-an-int")
+
+	if _, ok := key.Get(child); ok {
+		t.Fatal("expected a type-mismatched pair to be skipped")
+	}
+}
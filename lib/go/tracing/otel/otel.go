@@ -0,0 +1,109 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package otel bridges frugal's tracing.TracerProvider to a real
+// go.opentelemetry.io/otel/trace.TracerProvider, so frugal.FContext.StartSpan
+// opens an actual OpenTelemetry span and frugal.ToContext hands downstream
+// thrift handlers a context.Context that otel-instrumented code (otelhttp, an
+// otel-aware DB driver, etc.) recognizes via trace.SpanFromContext.
+package otel
+
+import (
+	"context"
+
+	otelcodes "go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	"github.com/Workiva/frugal/lib/go/tracing"
+)
+
+// TracerProvider adapts an oteltrace.TracerProvider to tracing.TracerProvider.
+// Register one with frugal.SetTracerProvider to have StartSpan create real
+// OpenTelemetry spans, e.g.:
+//
+//	frugal.SetTracerProvider(otel.NewTracerProvider(otel.GetTracerProvider()))
+type TracerProvider struct {
+	provider oteltrace.TracerProvider
+}
+
+// NewTracerProvider wraps provider as a tracing.TracerProvider.
+func NewTracerProvider(provider oteltrace.TracerProvider) *TracerProvider {
+	return &TracerProvider{provider: provider}
+}
+
+// Tracer implements tracing.TracerProvider.
+func (p *TracerProvider) Tracer(instrumentationName string) tracing.Tracer {
+	return &tracer{tracer: p.provider.Tracer(instrumentationName)}
+}
+
+type tracer struct {
+	tracer oteltrace.Tracer
+}
+
+// Start implements tracing.Tracer. When sc carries a ParentSpanID, it seeds
+// ctx with sc's trace-id and parent-span-id as a remote parent span context,
+// so the otel SDK's child span shares frugal's W3C trace-id; without one,
+// ctx is not seeded and the SDK picks the trace-id itself. It then starts a
+// real span and returns the context.Context it's embedded in.
+func (t *tracer) Start(ctx context.Context, name string, sc tracing.SpanContext) (context.Context, tracing.Span) {
+	if parentSC, ok := remoteSpanContext(sc); ok {
+		ctx = oteltrace.ContextWithRemoteSpanContext(ctx, parentSC)
+	}
+	ctx, span := t.tracer.Start(ctx, name)
+	return ctx, &tracingSpan{span: span}
+}
+
+// remoteSpanContext builds an oteltrace.SpanContext representing the span
+// that caused sc to be started, so the new span it seeds is a proper otel
+// child. It returns ok false if sc doesn't carry a valid parent to seed.
+func remoteSpanContext(sc tracing.SpanContext) (oteltrace.SpanContext, bool) {
+	if sc.ParentSpanID == "" {
+		return oteltrace.SpanContext{}, false
+	}
+	traceID, err := oteltrace.TraceIDFromHex(sc.TraceID)
+	if err != nil {
+		return oteltrace.SpanContext{}, false
+	}
+	spanID, err := oteltrace.SpanIDFromHex(sc.ParentSpanID)
+	if err != nil {
+		return oteltrace.SpanContext{}, false
+	}
+	return oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: oteltrace.FlagsSampled,
+		Remote:     true,
+	}), true
+}
+
+type tracingSpan struct {
+	span oteltrace.Span
+}
+
+// SetError implements tracing.Span.
+func (s *tracingSpan) SetError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(otelcodes.Error, err.Error())
+}
+
+// End implements tracing.Span.
+func (s *tracingSpan) End() {
+	s.span.End()
+}
+
+// SpanID implements tracing.Span, returning the span-id the OTel SDK
+// actually assigned (which may differ from the one frugal pre-generated
+// and passed into Start via sc.SpanID).
+func (s *tracingSpan) SpanID() string {
+	return s.span.SpanContext().SpanID().String()
+}
@@ -0,0 +1,54 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otel_test
+
+import (
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	frugal "github.com/Workiva/frugal/lib/go"
+	"github.com/Workiva/frugal/lib/go/tracing/otel"
+)
+
+// TestStartSpanReconcilesSDKAssignedSpanID guards against FContext.StartSpan
+// writing a traceparent header that references a span-id the configured
+// TracerProvider never actually recorded: the real OTel SDK always mints
+// its own span-id rather than reusing the one frugal pre-generates, so
+// StartSpan must read it back and correct the traceparent it serializes.
+func TestStartSpanReconcilesSDKAssignedSpanID(t *testing.T) {
+	provider := sdktrace.NewTracerProvider()
+	t.Cleanup(func() { frugal.SetTracerProvider(nil) })
+	frugal.SetTracerProvider(otel.NewTracerProvider(provider))
+
+	ctx := frugal.NewFContext("")
+	preSpanID := ctx.SpanID()
+
+	ctx, finish := ctx.StartSpan("test-span")
+	defer finish(nil)
+
+	if ctx.SpanID() == preSpanID {
+		t.Fatal("expected StartSpan to advance the span-id, but it kept the one from before the call")
+	}
+	if len(ctx.SpanID()) != 16 {
+		t.Fatalf("expected a 16 hex character span-id, got %q", ctx.SpanID())
+	}
+
+	goCtx, cancel := frugal.ToContext(ctx)
+	defer cancel()
+	if sdkSpanID := oteltrace.SpanFromContext(goCtx).SpanContext().SpanID().String(); ctx.SpanID() != sdkSpanID {
+		t.Fatalf("expected traceparent span-id %q to match the SDK-assigned span-id %q", ctx.SpanID(), sdkSpanID)
+	}
+}
@@ -0,0 +1,51 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otel
+
+import (
+	"testing"
+
+	"github.com/Workiva/frugal/lib/go/tracing"
+)
+
+func TestRemoteSpanContextRequiresParent(t *testing.T) {
+	if _, ok := remoteSpanContext(tracing.SpanContext{
+		TraceID: "0123456789abcdef0123456789abcdef",
+	}); ok {
+		t.Fatal("expected no remote span context without a parent span-id")
+	}
+}
+
+func TestRemoteSpanContextFromValidIDs(t *testing.T) {
+	sc, ok := remoteSpanContext(tracing.SpanContext{
+		TraceID:      "0123456789abcdef0123456789abcdef",
+		SpanID:       "fedcba9876543210",
+		ParentSpanID: "0123456789abcdef",
+	})
+	if !ok {
+		t.Fatal("expected a valid remote span context")
+	}
+	if !sc.IsValid() || !sc.IsRemote() {
+		t.Fatalf("expected a valid, remote span context, got %+v", sc)
+	}
+}
+
+func TestRemoteSpanContextRejectsMalformedIDs(t *testing.T) {
+	if _, ok := remoteSpanContext(tracing.SpanContext{
+		TraceID:      "not-hex",
+		ParentSpanID: "0123456789abcdef",
+	}); ok {
+		t.Fatal("expected malformed trace-id to be rejected")
+	}
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package tracing defines the pluggable abstraction FContext uses to open
+// spans for distributed tracing, so frugal's core package isn't hard-wired
+// to one tracing SDK. For a real OpenTelemetry-backed TracerProvider, see the
+// sibling tracing/otel package.
+package tracing
+
+import "context"
+
+// SpanContext carries the W3C Trace Context identifiers for a span that's
+// about to be started.
+type SpanContext struct {
+	// TraceID is the 32 hex character W3C trace-id.
+	TraceID string
+
+	// SpanID is the 16 hex character W3C span-id for the span being started.
+	SpanID string
+
+	// ParentSpanID is the span-id of the span that caused this one to be
+	// created, or empty if this is a root span.
+	ParentSpanID string
+}
+
+// Span is the subset of span behavior FContext needs in order to record the
+// outcome of an RPC.
+type Span interface {
+	// SetError marks the span as failed and records err on it.
+	SetError(err error)
+
+	// End completes the span.
+	End()
+
+	// SpanID returns the real span-id the underlying tracing SDK assigned
+	// to this span, as 16 hex characters, or the empty string if the SDK
+	// doesn't mint its own id and instead keeps whatever id it was given
+	// in SpanContext (as the no-op implementation does). FContext.StartSpan
+	// uses this to reconcile the traceparent header it serializes to the
+	// wire with the id a registered TracerProvider's exporter actually
+	// recorded, so a downstream hop's parent-span reference resolves.
+	SpanID() string
+}
+
+// Tracer starts spans for a named instrumentation library.
+type Tracer interface {
+	// Start begins a new span described by sc and returns a derived
+	// context.Context along with the Span handle used to end it.
+	Start(ctx context.Context, name string, sc SpanContext) (context.Context, Span)
+}
+
+// TracerProvider vends Tracers. Implementations adapt a concrete tracing SDK
+// (e.g. OpenTelemetry's trace.TracerProvider) to this interface.
+type TracerProvider interface {
+	// Tracer returns a Tracer for the given instrumentation name.
+	Tracer(instrumentationName string) Tracer
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetError(error) {}
+func (noopSpan) End()           {}
+func (noopSpan) SpanID() string { return "" }
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string, _ SpanContext) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopTracerProvider struct{}
+
+func (noopTracerProvider) Tracer(string) Tracer {
+	return noopTracer{}
+}
+
+// NoopTracerProvider is a TracerProvider whose spans do nothing. It's the
+// default until a real TracerProvider is registered.
+var NoopTracerProvider TracerProvider = noopTracerProvider{}